@@ -0,0 +1,212 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Resource string
+type Action string
+
+const (
+	ResourceEvents   Resource = "events"
+	ResourcePersons  Resource = "persons"
+	ResourceImages   Resource = "images"
+	ResourceAccounts Resource = "accounts"
+)
+
+const (
+	ActionSearch Action = "search"
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	ActionShare  Action = "share"
+)
+
+// "admin" bypasses role_permissions entirely; "guest" is the default role
+// for unauthenticated callers.
+const (
+	RoleAdmin = "admin"
+	RoleGuest = "guest"
+)
+
+const (
+	sessionCookieName = "session_token"
+	sessionDuration   = 24 * time.Hour
+)
+
+type Session struct {
+	SessionToken   string    `db:"session_token"`
+	AccountID      int       `db:"account_id"`
+	SessionExpires time.Time `db:"session_expires"`
+}
+
+type LoginRequest struct {
+	LoginName string `json:"login_name"`
+	Password  string `json:"password"`
+}
+
+// newSessionToken returns a random URL-safe token.
+func newSessionToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// POST /api/session
+// login_name/passwordを検証しセッションを発行、HttpOnly Cookieで返す
+func postSession(c echo.Context) error {
+	req := new(LoginRequest)
+	if err := c.Bind(req); err != nil {
+		c.Logger().Errorf("Bind error: %v", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	var account Account
+	err := db.Get(&account, "SELECT * FROM `accounts` WHERE `login_name` = ?", req.LoginName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusUnauthorized, "invalid credentials")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(account.ShadowPassword), []byte(req.Password)) != nil {
+		return c.String(http.StatusUnauthorized, "invalid credentials")
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		c.Logger().Errorf("token error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	expires := time.Now().Add(sessionDuration)
+
+	_, err = db.Exec("INSERT INTO `sessions` (`session_token`, `account_id`, `session_expires`) VALUES (?, ?, ?)",
+		token, account.AccountID, expires)
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Expires:  expires,
+		HttpOnly: true,
+		Path:     "/",
+	})
+	return c.JSON(http.StatusOK, account)
+}
+
+// DELETE /api/session
+// セッションを破棄しCookieを失効させる
+func deleteSession(c echo.Context) error {
+	cookie, err := c.Cookie(sessionCookieName)
+	if err == nil {
+		if _, err := db.Exec("DELETE FROM `sessions` WHERE `session_token` = ?", cookie.Value); err != nil {
+			c.Logger().Errorf("db error: %v", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+	c.SetCookie(&http.Cookie{Name: sessionCookieName, Value: "", MaxAge: -1, HttpOnly: true, Path: "/"})
+	return c.NoContent(http.StatusNoContent)
+}
+
+// sessionMiddleware resolves the session cookie (if any) into an Account,
+// falling back to a "guest" Account so public endpoints keep working.
+func sessionMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Set("account", &Account{Role: RoleGuest})
+
+		cookie, err := c.Cookie(sessionCookieName)
+		if err != nil || cookie.Value == "" {
+			return next(c)
+		}
+
+		var session Session
+		err = db.Get(&session, "SELECT * FROM `sessions` WHERE `session_token` = ?", cookie.Value)
+		if err != nil {
+			return next(c)
+		}
+		if time.Now().After(session.SessionExpires) {
+			return next(c)
+		}
+
+		var account Account
+		if err := db.Get(&account, "SELECT * FROM `accounts` WHERE `account_id` = ?", session.AccountID); err != nil {
+			return next(c)
+		}
+		c.Set("account", &account)
+		return next(c)
+	}
+}
+
+// currentAccount returns the Account resolved by sessionMiddleware.
+func currentAccount(c echo.Context) *Account {
+	if account, ok := c.Get("account").(*Account); ok && account != nil {
+		return account
+	}
+	return &Account{Role: RoleGuest}
+}
+
+// isOwnerOrAdmin reports whether account owns ownerAccountID's row or holds the admin role.
+func isOwnerOrAdmin(account *Account, ownerAccountID int) bool {
+	if account == nil {
+		return false
+	}
+	return account.Role == RoleAdmin || account.AccountID == ownerAccountID
+}
+
+// hasPermission consults role_permissions; the admin role always passes.
+func hasPermission(role string, resource Resource, action Action) (bool, error) {
+	if role == RoleAdmin {
+		return true, nil
+	}
+	var count int
+	err := db.Get(&count,
+		"SELECT COUNT(*) FROM `role_permissions` WHERE `role_name` = ? AND `resource` = ? AND `action` = ?",
+		role, resource, action)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// requireAdmin rejects the request with 403 unless the account holds the admin role.
+func requireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if currentAccount(c).Role != RoleAdmin {
+			return c.NoContent(http.StatusForbidden)
+		}
+		return next(c)
+	}
+}
+
+// requirePermission rejects the request with 403 unless the account's role
+// may perform action on resource.
+func requirePermission(resource Resource, action Action) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			account := currentAccount(c)
+			allowed, err := hasPermission(account.Role, resource, action)
+			if err != nil {
+				c.Logger().Errorf("db error: %v", err)
+				return c.NoContent(http.StatusInternalServerError)
+			}
+			if !allowed {
+				return c.NoContent(http.StatusForbidden)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestIsOwnerOrAdmin(t *testing.T) {
+	cases := []struct {
+		name    string
+		account *Account
+		ownerID int
+		want    bool
+	}{
+		{"nil account", nil, 1, false},
+		{"owner", &Account{AccountID: 1, Role: "member"}, 1, true},
+		{"not owner", &Account{AccountID: 2, Role: "member"}, 1, false},
+		{"admin, not owner", &Account{AccountID: 2, Role: RoleAdmin}, 1, true},
+		{"guest, not owner", &Account{AccountID: 0, Role: RoleGuest}, 1, false},
+	}
+	for _, c := range cases {
+		if got := isOwnerOrAdmin(c.account, c.ownerID); got != c.want {
+			t.Errorf("%s: isOwnerOrAdmin() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestHasPermissionAdminBypass(t *testing.T) {
+	// The admin role short-circuits before touching the database, so this
+	// is safe to exercise without a live connection.
+	allowed, err := hasPermission(RoleAdmin, ResourceEvents, ActionDelete)
+	if err != nil {
+		t.Fatalf("hasPermission(admin): %v", err)
+	}
+	if !allowed {
+		t.Error("admin role should always be allowed")
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/yaml.v3"
+)
+
+type EventYaml struct {
+	EventID     int              `yaml:"event_id"`
+	AccountID   int              `yaml:"account_id"`
+	Title       string           `yaml:"title"`
+	Description string           `yaml:"description"`
+	EventDate   time.Time        `yaml:"event_date"`
+	PersonIDs   []int            `yaml:"person_ids,omitempty"`
+	Images      []EventYamlImage `yaml:"images,omitempty"`
+}
+
+type EventYamlImage struct {
+	ImageID   int    `yaml:"image_id"`
+	ImageName string `yaml:"image_name"`
+}
+
+// backupYamlPath returns BACKUP_YAML_PATH, or "" if backups are disabled.
+func backupYamlPath() string {
+	return os.Getenv("BACKUP_YAML_PATH")
+}
+
+func eventYamlFilePath(dir string, eventID int) string {
+	return filepath.Join(dir, fmt.Sprintf("%d.yml", eventID))
+}
+
+// SaveEventAsYaml writes event (plus its bound persons/images) to BACKUP_YAML_PATH. A no-op when unset.
+func SaveEventAsYaml(event Event) error {
+	dir := backupYamlPath()
+	if dir == "" {
+		return nil
+	}
+
+	var personIDs []int
+	if err := db.Select(&personIDs, "SELECT `person_id` FROM `event_person_tagging` WHERE `event_id` = ?", event.EventID); err != nil {
+		return err
+	}
+
+	var images []EventYamlImage
+	err := db.Select(&images,
+		"SELECT `image_id`, `image_name` FROM `images` WHERE `image_id` IN "+
+			"(SELECT `image_id` FROM `event_image_tagging` WHERE `event_id` = ?)", event.EventID)
+	if err != nil {
+		return err
+	}
+
+	out := EventYaml{
+		EventID:     event.EventID,
+		AccountID:   event.AccountID,
+		Title:       event.Title,
+		Description: event.Description,
+		EventDate:   event.EventDate,
+		PersonIDs:   personIDs,
+		Images:      images,
+	}
+
+	data, err := yaml.Marshal(out)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(eventYamlFilePath(dir, event.EventID), data, 0o644)
+}
+
+// saveEventYamlByID loads eventID and writes its sidecar.
+func saveEventYamlByID(eventID string) error {
+	if backupYamlPath() == "" {
+		return nil
+	}
+	var event Event
+	if err := db.Get(&event, "SELECT * FROM `events` WHERE `event_id` = ?", eventID); err != nil {
+		return err
+	}
+	return SaveEventAsYaml(event)
+}
+
+// RemoveEventYaml deletes the sidecar for eventID, if any.
+func RemoveEventYaml(eventID int) error {
+	dir := backupYamlPath()
+	if dir == "" {
+		return nil
+	}
+	err := os.Remove(eventYamlFilePath(dir, eventID))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+type RestoreSummary struct {
+	Created []int `json:"created"`
+	Updated []int `json:"updated"`
+	Skipped []int `json:"skipped"`
+}
+
+// POST /api/events/restore
+// BACKUP_YAML_PATH配下のYAMLサイドカーを走査し、event_idを保持したままイベントと
+// 参加者・画像のバインドをupsertする（管理者向け）
+func postEventsRestore(c echo.Context) error {
+	dir := backupYamlPath()
+	if dir == "" {
+		return c.String(http.StatusBadRequest, "BACKUP_YAML_PATH not configured")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		c.Logger().Errorf("failed to read backup dir: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	var summary RestoreSummary
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yml") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			c.Logger().Errorf("failed to read %v: %v", entry.Name(), err)
+			continue
+		}
+		var in EventYaml
+		if err := yaml.Unmarshal(data, &in); err != nil {
+			c.Logger().Errorf("failed to parse %v: %v", entry.Name(), err)
+			continue
+		}
+
+		created, err := restoreEvent(in)
+		if err != nil {
+			c.Logger().Errorf("failed to restore %v: %v", entry.Name(), err)
+			summary.Skipped = append(summary.Skipped, in.EventID)
+			continue
+		}
+		if created {
+			summary.Created = append(summary.Created, in.EventID)
+		} else {
+			summary.Updated = append(summary.Updated, in.EventID)
+		}
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+// restoreEvent upserts event_id from the sidecar and rebinds its persons/images.
+func restoreEvent(in EventYaml) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var existing int
+	if err := tx.Get(&existing, "SELECT COUNT(*) FROM `events` WHERE `event_id` = ?", in.EventID); err != nil {
+		return false, err
+	}
+	created := existing == 0
+
+	if created {
+		_, err = tx.Exec("INSERT INTO `events` (`event_id`, `account_id`, `title`, `description`, `event_date`) VALUES (?, ?, ?, ?, ?)",
+			in.EventID, in.AccountID, in.Title, in.Description, in.EventDate)
+	} else {
+		_, err = tx.Exec("UPDATE `events` SET `account_id` = ?, `title` = ?, `description` = ?, `event_date` = ? WHERE `event_id` = ?",
+			in.AccountID, in.Title, in.Description, in.EventDate, in.EventID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM `event_person_tagging` WHERE `event_id` = ?", in.EventID); err != nil {
+		return false, err
+	}
+	for _, personID := range in.PersonIDs {
+		if _, err := tx.Exec("INSERT INTO `event_person_tagging` (`event_id`, `person_id`) VALUES (?, ?)", in.EventID, personID); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM `event_image_tagging` WHERE `event_id` = ?", in.EventID); err != nil {
+		return false, err
+	}
+	for _, image := range in.Images {
+		if _, err := tx.Exec("INSERT INTO `event_image_tagging` (`event_id`, `image_id`) VALUES (?, ?)", in.EventID, image.ImageID); err != nil {
+			return false, err
+		}
+	}
+
+	return created, tx.Commit()
+}
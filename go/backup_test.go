@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestBackupYamlPath(t *testing.T) {
+	os.Unsetenv("BACKUP_YAML_PATH")
+	if got := backupYamlPath(); got != "" {
+		t.Errorf("backupYamlPath() = %q, want empty when unset", got)
+	}
+
+	t.Setenv("BACKUP_YAML_PATH", "/tmp/backups")
+	if got := backupYamlPath(); got != "/tmp/backups" {
+		t.Errorf("backupYamlPath() = %q, want /tmp/backups", got)
+	}
+}
+
+func TestEventYamlFilePath(t *testing.T) {
+	got := eventYamlFilePath("/tmp/backups", 42)
+	want := filepath.Join("/tmp/backups", "42.yml")
+	if got != want {
+		t.Errorf("eventYamlFilePath() = %q, want %q", got, want)
+	}
+}
+
+func TestEventYamlRoundTrip(t *testing.T) {
+	in := EventYaml{
+		EventID:     1,
+		AccountID:   2,
+		Title:       "Sports Day",
+		Description: "annual event",
+		EventDate:   time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+		PersonIDs:   []int{10, 20},
+		Images:      []EventYamlImage{{ImageID: 100, ImageName: "a.png"}},
+	}
+
+	data, err := yaml.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var out EventYaml
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.EventID != in.EventID || out.AccountID != in.AccountID || out.Title != in.Title {
+		t.Errorf("round trip mismatch: got %+v, want %+v", out, in)
+	}
+	if len(out.PersonIDs) != 2 || out.PersonIDs[0] != 10 || out.PersonIDs[1] != 20 {
+		t.Errorf("PersonIDs round trip = %v", out.PersonIDs)
+	}
+	if len(out.Images) != 1 || out.Images[0].ImageID != 100 || out.Images[0].ImageName != "a.png" {
+		t.Errorf("Images round trip = %v", out.Images)
+	}
+}
+
+func TestSaveEventAsYamlDisabled(t *testing.T) {
+	os.Unsetenv("BACKUP_YAML_PATH")
+	if err := SaveEventAsYaml(Event{EventID: 1}); err != nil {
+		t.Errorf("SaveEventAsYaml should no-op when BACKUP_YAML_PATH is unset, got: %v", err)
+	}
+}
+
+func TestRemoveEventYamlDisabled(t *testing.T) {
+	os.Unsetenv("BACKUP_YAML_PATH")
+	if err := RemoveEventYaml(1); err != nil {
+		t.Errorf("RemoveEventYaml should no-op when BACKUP_YAML_PATH is unset, got: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"archive/zip"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/labstack/echo/v4"
+)
+
+var eventZipNameSanitizer = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// sanitizeZipFileName turns name into a filesystem-safe Content-Disposition fragment.
+func sanitizeZipFileName(name string) string {
+	sanitized := eventZipNameSanitizer.ReplaceAllString(name, "-")
+	if sanitized == "" {
+		return "event"
+	}
+	return sanitized
+}
+
+// sanitizeZipEntryName strips directory components from image_name so it
+// can't zip-slip outside the extraction directory.
+func sanitizeZipEntryName(name string) string {
+	base := filepath.Base(filepath.Clean(name))
+	if base == "" || base == "." || base == ".." || base == string(filepath.Separator) {
+		return "image"
+	}
+	return base
+}
+
+// dedupeZipEntryName suffixes name the first time it collides with an entry already written.
+func dedupeZipEntryName(used map[string]int, name string) string {
+	count := used[name]
+	used[name] = count + 1
+	if count == 0 {
+		return name
+	}
+	ext := ""
+	base := name
+	if idx := lastDot(name); idx >= 0 {
+		base, ext = name[:idx], name[idx:]
+	}
+	return fmt.Sprintf("%s (%d)%s", base, count, ext)
+}
+
+func lastDot(name string) int {
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '.' {
+			return i
+		}
+	}
+	return -1
+}
+
+// GET /api/events/{event_id}/download
+// イベントに紐づく画像一式をZIPとしてストリーム配信
+func downloadEventImages(c echo.Context) error {
+	eventID := c.Param("event_id")
+
+	var event Event
+	if err := db.Get(&event, "SELECT * FROM `events` WHERE `event_id` = ?", eventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: event")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	var imagelist Imagelist
+	err := db.Select(&imagelist, "select * from images where image_id in (select distinct(image_id) from event_image_tagging where event_id = ?)", eventID)
+	if err != nil {
+		c.Logger().Errorf("failed to query: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if len(imagelist) == 0 {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	filename := fmt.Sprintf("%s-%s.zip", sanitizeZipFileName(event.Title), eventID)
+	c.Response().Header().Set("Content-Type", "application/zip")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Response().WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(c.Response())
+
+	used := make(map[string]int)
+	for _, image := range imagelist {
+		entryName := dedupeZipEntryName(used, sanitizeZipEntryName(image.ImageName))
+		if err := writeImageZipEntry(zw, entryName, image.ImageId); err != nil {
+			c.Logger().Errorf("failed to add %v to zip, skipping: %v", image.ImageId, err)
+			continue
+		}
+	}
+	return zw.Close()
+}
+
+// writeImageZipEntry streams the on-disk PNG for imageID into the archive under entryName.
+func writeImageZipEntry(zw *zip.Writer, entryName string, imageID int) error {
+	src, err := os.Open(fmt.Sprintf("%s/%d.png", imagesPath, imageID))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.Create(entryName)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestSanitizeZipEntryName(t *testing.T) {
+	cases := map[string]string{
+		"photo.png":                     "photo.png",
+		"../../../../home/user/.bashrc": ".bashrc",
+		"../../etc/passwd":              "passwd",
+		"..":                            "image",
+		".":                             "image",
+		"":                              "image",
+		"/etc/passwd":                   "passwd",
+		"sub/dir/name.png":              "name.png",
+	}
+	for in, want := range cases {
+		if got := sanitizeZipEntryName(in); got != want {
+			t.Errorf("sanitizeZipEntryName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSanitizeZipFileName(t *testing.T) {
+	if got := sanitizeZipFileName("Summer Trip 2024!"); got != "Summer-Trip-2024-" {
+		t.Errorf("sanitizeZipFileName = %q", got)
+	}
+	if got := sanitizeZipFileName(""); got != "event" {
+		t.Errorf("sanitizeZipFileName(\"\") = %q, want \"event\"", got)
+	}
+}
+
+func TestDedupeZipEntryName(t *testing.T) {
+	used := make(map[string]int)
+	if got := dedupeZipEntryName(used, "photo.png"); got != "photo.png" {
+		t.Errorf("first entry = %q, want unsuffixed", got)
+	}
+	if got := dedupeZipEntryName(used, "photo.png"); got != "photo (1).png" {
+		t.Errorf("second entry = %q, want \"photo (1).png\"", got)
+	}
+	if got := dedupeZipEntryName(used, "photo.png"); got != "photo (2).png" {
+		t.Errorf("third entry = %q, want \"photo (2).png\"", got)
+	}
+}
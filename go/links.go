@@ -0,0 +1,311 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Link struct {
+	LinkID      int       `db:"link_id" json:"link_id"`
+	EventID     int       `db:"event_id" json:"event_id"`
+	LinkToken   string    `db:"link_token" json:"link_token"`
+	ShareSlug   string    `db:"share_slug" json:"share_slug"`
+	Password    string    `db:"password" json:"-"`
+	MaxViews    int       `db:"max_views" json:"max_views"`
+	Views       int       `db:"views" json:"views"`
+	LinkExpires int       `db:"link_expires" json:"link_expires"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+type Linklist []Link
+
+type NewLink struct {
+	ShareSlug   string `json:"share_slug"`
+	Password    string `json:"password"`
+	MaxViews    int    `json:"max_views"`
+	LinkExpires int    `json:"link_expires"`
+}
+
+// SetPassword hashes pw with bcrypt; an empty pw clears the password.
+func (l *Link) SetPassword(pw string) error {
+	if pw == "" {
+		l.Password = ""
+		return nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	l.Password = string(hash)
+	return nil
+}
+
+// CheckPassword reports whether pw matches the stored hash.
+func (l *Link) CheckPassword(pw string) bool {
+	if l.Password == "" {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(l.Password), []byte(pw)) == nil
+}
+
+// Expired reports whether the link has passed its LinkExpires window.
+func (l *Link) Expired() bool {
+	if l.LinkExpires <= 0 {
+		return false
+	}
+	return time.Now().After(l.CreatedAt.Add(time.Duration(l.LinkExpires) * time.Second))
+}
+
+// newLinkToken returns a random URL-safe token.
+func newLinkToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// POST /api/events/{event_id}/links
+// イベントの共有リンクを作成
+func postEventLink(c echo.Context) error {
+	eventID := c.Param("event_id")
+
+	var event Event
+	if err := db.Get(&event, "SELECT * FROM `events` WHERE `event_id` = ?", eventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: event")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if !isOwnerOrAdmin(currentAccount(c), event.AccountID) {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	newLink := new(NewLink)
+	if err := c.Bind(newLink); err != nil {
+		c.Logger().Errorf("Bind error: %v", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	token, err := newLinkToken()
+	if err != nil {
+		c.Logger().Errorf("token error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	link := Link{MaxViews: newLink.MaxViews, LinkExpires: newLink.LinkExpires, ShareSlug: newLink.ShareSlug}
+	if err := link.SetPassword(newLink.Password); err != nil {
+		c.Logger().Errorf("hash error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO `event_links` "+
+		"(`link_id`, `event_id`, `link_token`, `share_slug`, `password`, `max_views`, `views`, `link_expires`, `created_at`) "+
+		"VALUES (default, ?, ?, ?, ?, 0, ?, ?, now())",
+		eventID, token, link.ShareSlug, link.Password, link.MaxViews, link.LinkExpires)
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if err := tx.Commit(); err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	ret := map[string]interface{}{"LinkID": id, "LinkToken": token}
+	return c.JSON(http.StatusCreated, ret)
+}
+
+// GET /api/events/{event_id}/links
+// イベントの共有リンク一覧を取得
+func getEventLinkList(c echo.Context) error {
+	eventID := c.Param("event_id")
+
+	var event Event
+	if err := db.Get(&event, "SELECT * FROM `events` WHERE `event_id` = ?", eventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: event")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if !isOwnerOrAdmin(currentAccount(c), event.AccountID) {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	var linklist Linklist
+	err := db.Select(&linklist, "SELECT * FROM `event_links` WHERE `event_id` = ?", eventID)
+	if err != nil {
+		c.Logger().Errorf("failed to query: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, linklist)
+}
+
+// PUT /api/events/{event_id}/links/{link_id}
+// 共有リンクの設定を更新
+func putEventLink(c echo.Context) error {
+	eventID := c.Param("event_id")
+	linkID := c.Param("link_id")
+
+	var event Event
+	if err := db.Get(&event, "SELECT * FROM `events` WHERE `event_id` = ?", eventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: event")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if !isOwnerOrAdmin(currentAccount(c), event.AccountID) {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	var link Link
+	err := db.Get(&link, "SELECT * FROM `event_links` WHERE `link_id` = ? AND `event_id` = ?", linkID, eventID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: link")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	newLink := new(NewLink)
+	if err := c.Bind(newLink); err != nil {
+		c.Logger().Errorf("Bind error: %v", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	link.ShareSlug = newLink.ShareSlug
+	link.MaxViews = newLink.MaxViews
+	link.LinkExpires = newLink.LinkExpires
+	if err := link.SetPassword(newLink.Password); err != nil {
+		c.Logger().Errorf("hash error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("UPDATE `event_links` SET `share_slug` = ?, `password` = ?, `max_views` = ?, `link_expires` = ? WHERE `link_id` = ?",
+		link.ShareSlug, link.Password, link.MaxViews, link.LinkExpires, linkID)
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if err := tx.Commit(); err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// DELETE /api/events/{event_id}/links/{link_id}
+// 共有リンクの削除
+func deleteEventLink(c echo.Context) error {
+	eventID := c.Param("event_id")
+	linkID := c.Param("link_id")
+
+	var event Event
+	if err := db.Get(&event, "SELECT * FROM `events` WHERE `event_id` = ?", eventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: event")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if !isOwnerOrAdmin(currentAccount(c), event.AccountID) {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec("DELETE FROM `event_links` WHERE `link_id` = ? AND `event_id` = ?", linkID, eventID)
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if err := tx.Commit(); err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GET /s/{token}/{slug}
+// 共有リンク経由でイベント詳細を取得（認証不要、パスワード・期限・閲覧回数を検証）
+func getSharedEvent(c echo.Context) error {
+	token := c.Param("token")
+
+	var link Link
+	err := db.Get(&link, "SELECT * FROM `event_links` WHERE `link_token` = ?", token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: link")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if link.Expired() {
+		return c.String(http.StatusGone, "expired: link")
+	}
+	if link.Password != "" {
+		// Header, not query param: the access log records the full request URI.
+		pw := c.Request().Header.Get("X-Link-Password")
+		if pw == "" {
+			return c.String(http.StatusUnauthorized, "password required")
+		}
+		if !link.CheckPassword(pw) {
+			return c.String(http.StatusUnauthorized, "wrong password")
+		}
+	}
+
+	// The bounds check and the increment happen in one statement so
+	// concurrent requests against a link with max_views set can't all pass
+	// a separate check before any of them commits.
+	res, err := db.Exec(
+		"UPDATE `event_links` SET `views` = `views` + 1 WHERE `link_id` = ? AND (`max_views` = 0 OR `views` < `max_views`)",
+		link.LinkID)
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if affected == 0 {
+		return c.String(http.StatusGone, "exhausted: link")
+	}
+
+	return writeEventDetail(c, strconv.Itoa(link.EventID))
+}
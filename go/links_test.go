@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestLinkExpired(t *testing.T) {
+	link := Link{LinkExpires: 0}
+	if link.Expired() {
+		t.Error("LinkExpires = 0 should never expire")
+	}
+}
+
+func TestLinkPasswordRoundTrip(t *testing.T) {
+	var link Link
+	if err := link.SetPassword("hunter2"); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	if !link.CheckPassword("hunter2") {
+		t.Error("CheckPassword should accept the password it was set with")
+	}
+	if link.CheckPassword("wrong") {
+		t.Error("CheckPassword should reject an incorrect password")
+	}
+
+	if err := link.SetPassword(""); err != nil {
+		t.Fatalf("SetPassword(\"\"): %v", err)
+	}
+	if !link.CheckPassword("anything") {
+		t.Error("a cleared password should accept any input")
+	}
+}
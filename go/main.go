@@ -36,9 +36,10 @@ type MySQLConnectionEnv struct {
 }
 
 type Account struct {
-	AccountID      int    `db:"account_id"`
-	LoginName      string `db:"login_name"`
-	ShadowPassword string `db:"shadow_password"`
+	AccountID      int    `db:"account_id" json:"account_id"`
+	LoginName      string `db:"login_name" json:"login_name"`
+	Role           string `db:"role" json:"role"`
+	ShadowPassword string `db:"shadow_password" json:"-"`
 }
 type Accountlist []Account
 
@@ -51,9 +52,10 @@ type Event struct {
 }
 type Eventlist []Event
 type EventDatail struct {
-	Event   *Event      `json:"event"`
-	Persons *Personlist `json:"persons"`
-	ImageAndPaths *ImageAndPathlist `json:"images"`}
+	Event         *Event            `json:"event"`
+	Persons       *Personlist       `json:"persons"`
+	ImageAndPaths *ImageAndPathlist `json:"images"`
+}
 
 type NewEvent struct {
 	Title       string `json:"title"`
@@ -67,13 +69,15 @@ type Person struct {
 }
 type Personlist []Person
 type Image struct {
-	ImageId  int    `db:"image_id" json:"image_id"`
-	ImageName string `db:"image_name" json:"image_name"`
-	ContentType  string `db:"mime_type" json:"content_type"`
+	ImageId     int    `db:"image_id" json:"image_id"`
+	AccountID   int    `db:"account_id" json:"account_id"`
+	ImageName   string `db:"image_name" json:"image_name"`
+	ContentType string `db:"mime_type" json:"content_type"`
 }
 type Imagelist []Image
 type ImageAndPath struct {
 	ImagePath string
+	Thumbs    map[string]string `json:"thumbs"`
 	Image
 }
 type ImageAndPathlist []ImageAndPath
@@ -111,28 +115,43 @@ func main() {
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
+	e.Use(sessionMiddleware)
 
 	// API Routes
 	e.GET("/api/test", hello)
-	e.GET("/api/accounts", accounts)
+	e.GET("/api/accounts", accounts, requirePermission(ResourceAccounts, ActionSearch))
+
+	e.POST("/api/session", postSession)
+	e.DELETE("/api/session", deleteSession)
+
+	e.GET("/api/events", getEventList, requirePermission(ResourceEvents, ActionSearch))
+	e.GET("/api/events/:event_id", getEvent, requirePermission(ResourceEvents, ActionSearch))
+	e.POST("/api/events", postEvent, requirePermission(ResourceEvents, ActionCreate))
+	e.DELETE("/api/events/:event_id", deleteEvent, requirePermission(ResourceEvents, ActionDelete))
 
-	e.GET("/api/events", getEventList)
-	e.GET("/api/events/:event_id", getEvent)
-	e.POST("/api/events", postEvent)
-	e.DELETE("/api/events/:event_id", deleteEvent)
+	e.GET("/api/persons", getPersonList, requirePermission(ResourcePersons, ActionSearch))
+	e.GET("/api/persons/:person_id", getPerson, requirePermission(ResourcePersons, ActionSearch))
+	e.POST("/api/persons", postPerson, requirePermission(ResourcePersons, ActionCreate))
+	e.DELETE("/api/persons/:person_id", deletePerson, requirePermission(ResourcePersons, ActionDelete))
 
-	e.GET("/api/persons", getPersonList)
-	e.GET("/api/persons/:person_id", getPerson)
-	e.POST("/api/persons", postPerson)
-	e.DELETE("/api/persons/:person_id", deletePerson)
+	e.GET("/api/images", getImageList, requirePermission(ResourceImages, ActionSearch))
+	e.GET("/api/images/:image_id", getImage, requirePermission(ResourceImages, ActionSearch))
+	e.POST("/api/images", uploadImage, requirePermission(ResourceImages, ActionCreate))
+	e.DELETE("/api/images/:image_id", deleteImage, requirePermission(ResourceImages, ActionDelete))
+	e.GET("/api/images/:image_id/thumb/:size", getImageThumb, requirePermission(ResourceImages, ActionSearch))
 
-	e.GET("/api/images", getImageList)
-	e.GET("/api/images/:image_id", getImage)
-	e.POST("/api/images", uploadImage)
-	e.DELETE("/api/images/:image_id", deleteImage)
+	e.GET("/api/events/:event_id/download", downloadEventImages, requirePermission(ResourceEvents, ActionSearch))
+	e.POST("/api/events/restore", postEventsRestore, requireAdmin)
 
-	e.POST("/api/events/:event_id/persons", bindEventPersons)
-	e.POST("/api/events/:event_id/images", bindEventImages)
+	e.POST("/api/events/:event_id/persons", bindEventPersons, requirePermission(ResourceEvents, ActionUpdate))
+	e.POST("/api/events/:event_id/images", bindEventImages, requirePermission(ResourceEvents, ActionUpdate))
+
+	e.POST("/api/events/:event_id/links", postEventLink, requirePermission(ResourceEvents, ActionShare))
+	e.GET("/api/events/:event_id/links", getEventLinkList, requirePermission(ResourceEvents, ActionShare))
+	e.PUT("/api/events/:event_id/links/:link_id", putEventLink, requirePermission(ResourceEvents, ActionShare))
+	e.DELETE("/api/events/:event_id/links/:link_id", deleteEventLink, requirePermission(ResourceEvents, ActionShare))
+	e.GET("/s/:token/:slug", getSharedEvent)
+	e.GET("/s/:token", getSharedEvent)
 
 	// Static Resource Routes
 	e.GET("/", getIndex)
@@ -165,7 +184,7 @@ func getIndex(c echo.Context) error {
 }
 
 func accounts(c echo.Context) error {
-	rows, err := db.Queryx(`select account_id, login_name, shadow_password from accounts`)
+	rows, err := db.Queryx(`select account_id, login_name, role, shadow_password from accounts`)
 	if err != nil {
 		c.Logger().Errorf("failed to query: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
@@ -186,15 +205,34 @@ func accounts(c echo.Context) error {
 // GET api/events/
 // イベントリストの取得
 func getEventList(c echo.Context) error {
-	limit := c.QueryParam("limit")
-	if limit != "" {
-		limit = fmt.Sprintf(" limit " + limit)
+	f := new(EventSearch)
+	if err := c.Bind(f); err != nil {
+		c.Logger().Errorf("Bind error: %v", err)
+		return c.NoContent(http.StatusBadRequest)
 	}
-	offset := c.QueryParam("offset")
-	if offset != "" {
-		offset = fmt.Sprintf(" offset " + offset)
+	f.normalize()
+
+	where, args, err := f.where()
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
 	}
-	rows, err := db.Queryx(`select event_id, title, description, event_date from events` + limit + offset)
+
+	var total int
+	countQuery, countArgs, err := rebindIn("SELECT COUNT(*) FROM `events`"+where, args)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := db.Get(&total, countQuery, countArgs...); err != nil {
+		c.Logger().Errorf("failed to query: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	query := "SELECT `event_id`, `title`, `description`, `event_date` FROM `events`" + where + f.orderBy() + " LIMIT ? OFFSET ?"
+	query, queryArgs, err := rebindIn(query, append(append([]interface{}{}, args...), f.Count, f.Offset))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	rows, err := db.Queryx(query, queryArgs...)
 	if err != nil {
 		c.Logger().Errorf("failed to query: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
@@ -210,14 +248,20 @@ func getEventList(c echo.Context) error {
 		eventlist = append(eventlist, event)
 	}
 
+	setResultHeaders(c, len(eventlist), f.Offset, total)
 	return c.JSON(http.StatusOK, eventlist)
 }
 
 // GET api/events/{event_id}
 // 個々のイベントの取得（参加者や画像URL等の詳細情報付き）
 func getEvent(c echo.Context) error {
-	eventID := c.Param("event_id")
+	return writeEventDetail(c, c.Param("event_id"))
+}
 
+// writeEventDetail builds the same event+persons+images payload used by
+// getEvent and writes it as the response. Shared with the share-link
+// handler so both paths stay in sync.
+func writeEventDetail(c echo.Context, eventID string) error {
 	var event Event
 	err := db.Get(&event, "SELECT * FROM `events` WHERE `event_id` = ?", eventID)
 	if err != nil {
@@ -259,14 +303,15 @@ func getEvent(c echo.Context) error {
 			return c.NoContent(http.StatusInternalServerError)
 		}
 		imageAndPath.Image = image
-		imageAndPath.ImagePath = fmt.Sprintf("/images/%d.png",image.ImageId)
+		imageAndPath.ImagePath = fmt.Sprintf("/images/%d.png", image.ImageId)
+		imageAndPath.Thumbs = thumbURLs(image.ImageId)
 		imageAndPathlist = append(imageAndPathlist, imageAndPath)
 	}
 
 	var res EventDatail
 	res = EventDatail{
-		Event:   &event,
-		Persons: &personlist,
+		Event:         &event,
+		Persons:       &personlist,
 		ImageAndPaths: &imageAndPathlist,
 	}
 
@@ -285,6 +330,8 @@ func postEvent(c echo.Context) error {
 	eventDate := event.EventDate
 	c.Logger().Errorf("info: %v", event)
 
+	accountID := currentAccount(c).AccountID
+
 	tx, err := db.Beginx()
 	if err != nil {
 		c.Logger().Errorf("db error: %v", err)
@@ -293,8 +340,8 @@ func postEvent(c echo.Context) error {
 	defer tx.Rollback()
 
 	_, err = tx.Exec("INSERT INTO `events`"+
-		"	(`event_id`, `account_id`, `title`, `description`, `event_date`) VALUES (default,1, ?, ?, ?)",
-		title, description, eventDate)
+		"	(`event_id`, `account_id`, `title`, `description`, `event_date`) VALUES (default, ?, ?, ?, ?)",
+		accountID, title, description, eventDate)
 	if err != nil {
 		mysqlErr, ok := err.(*mysql.MySQLError)
 
@@ -317,6 +364,12 @@ func postEvent(c echo.Context) error {
 		c.Logger().Errorf("db error: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
+
+	savedEvent := Event{EventID: id, AccountID: accountID, Title: title, Description: description, EventDate: time.Unix(eventDate, 0)}
+	if err := SaveEventAsYaml(savedEvent); err != nil {
+		c.Logger().Errorf("failed to save yaml backup: %v", err)
+	}
+
 	ret := map[string]int{"EventID": id}
 	return c.JSON(http.StatusCreated, ret)
 }
@@ -326,6 +379,18 @@ func postEvent(c echo.Context) error {
 func deleteEvent(c echo.Context) error {
 	eventID := c.Param("event_id")
 
+	var event Event
+	if err := db.Get(&event, "SELECT * FROM `events` WHERE `event_id` = ?", eventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: event")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if !isOwnerOrAdmin(currentAccount(c), event.AccountID) {
+		return c.NoContent(http.StatusForbidden)
+	}
+
 	tx, err := db.Beginx()
 	if err != nil {
 		c.Logger().Errorf("db error: %v", err)
@@ -342,20 +407,42 @@ func deleteEvent(c echo.Context) error {
 		c.Logger().Errorf("db error: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
+	if err := RemoveEventYaml(event.EventID); err != nil {
+		c.Logger().Errorf("failed to remove yaml backup: %v", err)
+	}
 	return c.NoContent(http.StatusNoContent)
 }
 
 // GET /api/persons
 func getPersonList(c echo.Context) error {
-	limit := c.QueryParam("limit")
-	if limit != "" {
-		limit = fmt.Sprintf(" limit " + limit)
+	f := new(PersonSearch)
+	if err := c.Bind(f); err != nil {
+		c.Logger().Errorf("Bind error: %v", err)
+		return c.NoContent(http.StatusBadRequest)
 	}
-	offset := c.QueryParam("offset")
-	if offset != "" {
-		offset = fmt.Sprintf(" offset " + offset)
+	f.normalize()
+
+	where, args, err := f.where()
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
 	}
-	rows, err := db.Queryx("select * from persons" + limit + offset)
+
+	var total int
+	countQuery, countArgs, err := rebindIn("SELECT COUNT(*) FROM `persons`"+where, args)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := db.Get(&total, countQuery, countArgs...); err != nil {
+		c.Logger().Errorf("failed to query: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	query := "SELECT * FROM `persons`" + where + f.orderBy() + " LIMIT ? OFFSET ?"
+	query, queryArgs, err := rebindIn(query, append(append([]interface{}{}, args...), f.Count, f.Offset))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	rows, err := db.Queryx(query, queryArgs...)
 	if err != nil {
 		c.Logger().Errorf("failed to query: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
@@ -370,6 +457,7 @@ func getPersonList(c echo.Context) error {
 		}
 		personlist = append(personlist, person)
 	}
+	setResultHeaders(c, len(personlist), f.Offset, total)
 	return c.JSON(http.StatusOK, personlist)
 }
 
@@ -386,7 +474,7 @@ func getPerson(c echo.Context) error {
 		c.Logger().Errorf("db error: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
-	return c.JSON(http.StatusOK,person)
+	return c.JSON(http.StatusOK, person)
 }
 
 // POST /api/persons
@@ -396,10 +484,10 @@ func postPerson(c echo.Context) error {
 	if err := c.Bind(&person); err != nil {
 		c.Logger().Errorf("Bind error: %v", err)
 	}
-	firstname:= person.FirstName
-	lastname := person.LastName 
-	c.Logger().Errorf("info: [%v] [%v] [%v]", person,firstname,lastname)
-	
+	firstname := person.FirstName
+	lastname := person.LastName
+	c.Logger().Errorf("info: [%v] [%v] [%v]", person, firstname, lastname)
+
 	// DBに行追加
 	tx, err := db.Beginx()
 	if err != nil {
@@ -437,6 +525,7 @@ func postPerson(c echo.Context) error {
 	ret := map[string]int{"PersonID": id}
 	return c.JSON(http.StatusCreated, ret)
 }
+
 // DELETE api/persons/{person_id}
 // 個人の削除（※まずは単純削除：ToDo タグなどのBindの掃除）
 func deletePerson(c echo.Context) error {
@@ -463,21 +552,40 @@ func deletePerson(c echo.Context) error {
 
 // GET /api/images
 func getImageList(c echo.Context) error {
-	limit := c.QueryParam("limit")
-	if limit != "" {
-		limit = fmt.Sprintf(" limit " + limit)
+	f := new(ImageSearch)
+	if err := c.Bind(f); err != nil {
+		c.Logger().Errorf("Bind error: %v", err)
+		return c.NoContent(http.StatusBadRequest)
 	}
-	offset := c.QueryParam("offset")
-	if offset != "" {
-		offset = fmt.Sprintf(" offset " + offset)
+	f.normalize()
+
+	where, args, err := f.where()
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
 	}
-	rows, err := db.Queryx("select * from images" + limit + offset)
+
+	var total int
+	countQuery, countArgs, err := rebindIn("SELECT COUNT(*) FROM `images`"+where, args)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	if err := db.Get(&total, countQuery, countArgs...); err != nil {
+		c.Logger().Errorf("failed to query: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	query := "SELECT * FROM `images`" + where + f.orderBy() + " LIMIT ? OFFSET ?"
+	query, queryArgs, err := rebindIn(query, append(append([]interface{}{}, args...), f.Count, f.Offset))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+	rows, err := db.Queryx(query, queryArgs...)
 	if err != nil {
 		c.Logger().Errorf("failed to query: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
 	var image Image
-//	var imagelist Imagelist
+	//	var imagelist Imagelist
 	var imageAndPath ImageAndPath
 	var imageAndPathList []ImageAndPath
 	for rows.Next() {
@@ -487,11 +595,14 @@ func getImageList(c echo.Context) error {
 			return c.NoContent(http.StatusInternalServerError)
 		}
 		imageAndPath.Image = image
-		imageAndPath.ImagePath = fmt.Sprintf("/images/%d.png",image.ImageId)
+		imageAndPath.ImagePath = fmt.Sprintf("/images/%d.png", image.ImageId)
+		imageAndPath.Thumbs = thumbURLs(image.ImageId)
 		imageAndPathList = append(imageAndPathList, imageAndPath)
 	}
+	setResultHeaders(c, len(imageAndPathList), f.Offset, total)
 	return c.JSON(http.StatusOK, imageAndPathList)
 }
+
 // GET /api/images/{image_id}
 func getImage(c echo.Context) error {
 	imageID := c.Param("image_id")
@@ -505,11 +616,12 @@ func getImage(c echo.Context) error {
 		c.Logger().Errorf("db error: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
-	var imageAndPath ImageAndPath 
+	var imageAndPath ImageAndPath
 	imageAndPath.Image = image
-	imageAndPath.ImagePath = fmt.Sprintf("/images/%d.png",image.ImageId)
+	imageAndPath.ImagePath = fmt.Sprintf("/images/%d.png", image.ImageId)
+	imageAndPath.Thumbs = thumbURLs(image.ImageId)
 
-	return c.JSON(http.StatusOK,imageAndPath)
+	return c.JSON(http.StatusOK, imageAndPath)
 }
 
 func uploadImage(c echo.Context) error {
@@ -526,6 +638,8 @@ func uploadImage(c echo.Context) error {
 	}
 	src, err := file.Open()
 
+	accountID := currentAccount(c).AccountID
+
 	// DBに行追加
 	tx, err := db.Beginx()
 	if err != nil {
@@ -533,7 +647,7 @@ func uploadImage(c echo.Context) error {
 		return c.NoContent(http.StatusInternalServerError)
 	}
 	defer tx.Rollback()
-	_, err = tx.Exec("INSERT INTO `images` (`image_id`, `image_name`, `mime_type`) VALUES (default, ?, ?)", image_name, mime_type)
+	_, err = tx.Exec("INSERT INTO `images` (`image_id`, `account_id`, `image_name`, `mime_type`) VALUES (default, ?, ?, ?)", accountID, image_name, mime_type)
 	if err != nil {
 		mysqlErr, ok := err.(*mysql.MySQLError)
 		if ok && mysqlErr.Number == uint16(mysqlErrNumDuplicateEntry) {
@@ -574,11 +688,24 @@ func uploadImage(c echo.Context) error {
 	ret := map[string]int{"ImageID": image_id}
 	return c.JSON(http.StatusCreated, ret)
 }
+
 // DELETE  /api/images/{image_id}
 // 画像の削除（※ファイル自体は残しDBから削除。ファイル削除は別途バッチ要。※ToDo:バインド掃除）
 func deleteImage(c echo.Context) error {
 	imageID := c.Param("image_id")
 
+	var image Image
+	if err := db.Get(&image, "SELECT * FROM `images` WHERE `image_id` = ?", imageID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: image")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	if !isOwnerOrAdmin(currentAccount(c), image.AccountID) {
+		return c.NoContent(http.StatusForbidden)
+	}
+
 	tx, err := db.Beginx()
 	if err != nil {
 		c.Logger().Errorf("db error: %v", err)
@@ -595,6 +722,9 @@ func deleteImage(c echo.Context) error {
 		c.Logger().Errorf("db error: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
+	if err := ClearImageThumbCache(image.ImageId); err != nil {
+		c.Logger().Errorf("failed to clear thumb cache: %v", err)
+	}
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -636,6 +766,9 @@ func bindEventPersons(c echo.Context) error {
 		c.Logger().Errorf("db error: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
+	if err := saveEventYamlByID(eventID); err != nil {
+		c.Logger().Errorf("failed to save yaml backup: %v", err)
+	}
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -677,6 +810,8 @@ func bindEventImages(c echo.Context) error {
 		c.Logger().Errorf("db error: %v", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
+	if err := saveEventYamlByID(eventID); err != nil {
+		c.Logger().Errorf("failed to save yaml backup: %v", err)
+	}
 	return c.NoContent(http.StatusNoContent)
 }
-
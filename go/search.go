@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	searchDefaultCount = 100
+	searchMaxCount     = 1000
+)
+
+type EventSearch struct {
+	Q         string `query:"q"`
+	Before    string `query:"before"`
+	After     string `query:"after"`
+	PersonID  int    `query:"person_id"`
+	ImageID   int    `query:"image_id"`
+	AccountID int    `query:"account_id"`
+	Order     string `query:"order"`
+	Count     int    `query:"count"`
+	Offset    int    `query:"offset"`
+}
+
+type PersonSearch struct {
+	Q       string `query:"q"`
+	EventID int    `query:"event_id"`
+	Order   string `query:"order"`
+	Count   int    `query:"count"`
+	Offset  int    `query:"offset"`
+}
+
+type ImageSearch struct {
+	Q         string `query:"q"`
+	EventID   int    `query:"event_id"`
+	AccountID int    `query:"account_id"`
+	Order     string `query:"order"`
+	Count     int    `query:"count"`
+	Offset    int    `query:"offset"`
+}
+
+// normalize clamps Count/Offset to their defaults and bounds.
+func (f *EventSearch) normalize() {
+	f.Count = normalizeCount(f.Count)
+	f.Offset = normalizeOffset(f.Offset)
+}
+func (f *PersonSearch) normalize() {
+	f.Count = normalizeCount(f.Count)
+	f.Offset = normalizeOffset(f.Offset)
+}
+func (f *ImageSearch) normalize() {
+	f.Count = normalizeCount(f.Count)
+	f.Offset = normalizeOffset(f.Offset)
+}
+
+func normalizeCount(count int) int {
+	if count <= 0 {
+		return searchDefaultCount
+	}
+	if count > searchMaxCount {
+		return searchMaxCount
+	}
+	return count
+}
+
+func normalizeOffset(offset int) int {
+	if offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+// where builds the WHERE clause and bound args, empty when there are no conditions.
+func (f *EventSearch) where() (string, []interface{}, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.Q != "" {
+		conds = append(conds, "(`title` LIKE ? OR `description` LIKE ?)")
+		like := "%" + f.Q + "%"
+		args = append(args, like, like)
+	}
+	if f.Before != "" {
+		t, err := time.Parse(time.RFC3339, f.Before)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid before: %w", err)
+		}
+		conds = append(conds, "`event_date` < ?")
+		args = append(args, t)
+	}
+	if f.After != "" {
+		t, err := time.Parse(time.RFC3339, f.After)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid after: %w", err)
+		}
+		conds = append(conds, "`event_date` > ?")
+		args = append(args, t)
+	}
+	if f.PersonID != 0 {
+		conds = append(conds, "`event_id` IN (SELECT `event_id` FROM `event_person_tagging` WHERE `person_id` = ?)")
+		args = append(args, f.PersonID)
+	}
+	if f.ImageID != 0 {
+		conds = append(conds, "`event_id` IN (SELECT `event_id` FROM `event_image_tagging` WHERE `image_id` = ?)")
+		args = append(args, f.ImageID)
+	}
+	if f.AccountID != 0 {
+		conds = append(conds, "`account_id` = ?")
+		args = append(args, f.AccountID)
+	}
+
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// orderBy maps Order to a whitelisted ORDER BY clause, defaulting to newest-first.
+func (f *EventSearch) orderBy() string {
+	switch f.Order {
+	case "oldest":
+		return " ORDER BY `event_date` ASC"
+	case "title":
+		return " ORDER BY `title` ASC"
+	default:
+		return " ORDER BY `event_date` DESC"
+	}
+}
+
+func (f *PersonSearch) where() (string, []interface{}, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.Q != "" {
+		conds = append(conds, "(`first_name` LIKE ? OR `last_name` LIKE ?)")
+		like := "%" + f.Q + "%"
+		args = append(args, like, like)
+	}
+	if f.EventID != 0 {
+		conds = append(conds, "`person_id` IN (SELECT `person_id` FROM `event_person_tagging` WHERE `event_id` = ?)")
+		args = append(args, f.EventID)
+	}
+
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+func (f *PersonSearch) orderBy() string {
+	switch f.Order {
+	case "oldest":
+		return " ORDER BY `person_id` ASC"
+	case "title":
+		return " ORDER BY `last_name` ASC, `first_name` ASC"
+	default:
+		return " ORDER BY `person_id` DESC"
+	}
+}
+
+func (f *ImageSearch) where() (string, []interface{}, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.Q != "" {
+		conds = append(conds, "`image_name` LIKE ?")
+		args = append(args, "%"+f.Q+"%")
+	}
+	if f.EventID != 0 {
+		conds = append(conds, "`image_id` IN (SELECT `image_id` FROM `event_image_tagging` WHERE `event_id` = ?)")
+		args = append(args, f.EventID)
+	}
+	if f.AccountID != 0 {
+		conds = append(conds, "`account_id` = ?")
+		args = append(args, f.AccountID)
+	}
+
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+func (f *ImageSearch) orderBy() string {
+	switch f.Order {
+	case "oldest":
+		return " ORDER BY `image_id` ASC"
+	case "title":
+		return " ORDER BY `image_name` ASC"
+	default:
+		return " ORDER BY `image_id` DESC"
+	}
+}
+
+// rebindIn expands slice args via sqlx.In and rebinds placeholders for the current driver.
+func rebindIn(query string, args []interface{}) (string, []interface{}, error) {
+	if len(args) == 0 {
+		return query, args, nil
+	}
+	q, a, err := sqlx.In(query, args...)
+	if err != nil {
+		return "", nil, err
+	}
+	return db.Rebind(q), a, nil
+}
+
+// setResultHeaders sets the X-Result-* headers for paginated list endpoints.
+func setResultHeaders(c echo.Context, count, offset, total int) {
+	h := c.Response().Header()
+	h.Set("X-Result-Count", strconv.Itoa(count))
+	h.Set("X-Result-Offset", strconv.Itoa(offset))
+	h.Set("X-Result-Total", strconv.Itoa(total))
+}
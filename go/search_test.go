@@ -0,0 +1,97 @@
+package main
+
+import "testing"
+
+func TestNormalizeCount(t *testing.T) {
+	cases := []struct{ in, want int }{
+		{0, searchDefaultCount},
+		{-5, searchDefaultCount},
+		{50, 50},
+		{searchMaxCount, searchMaxCount},
+		{searchMaxCount + 1, searchMaxCount},
+	}
+	for _, c := range cases {
+		if got := normalizeCount(c.in); got != c.want {
+			t.Errorf("normalizeCount(%d) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeOffset(t *testing.T) {
+	cases := []struct{ in, want int }{
+		{0, 0},
+		{-1, 0},
+		{50, 50},
+	}
+	for _, c := range cases {
+		if got := normalizeOffset(c.in); got != c.want {
+			t.Errorf("normalizeOffset(%d) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestEventSearchOrderBy(t *testing.T) {
+	cases := []struct {
+		order string
+		want  string
+	}{
+		{"oldest", " ORDER BY `event_date` ASC"},
+		{"title", " ORDER BY `title` ASC"},
+		{"", " ORDER BY `event_date` DESC"},
+		{"bogus", " ORDER BY `event_date` DESC"},
+	}
+	for _, c := range cases {
+		f := EventSearch{Order: c.order}
+		if got := f.orderBy(); got != c.want {
+			t.Errorf("EventSearch{Order:%q}.orderBy() = %q, want %q", c.order, got, c.want)
+		}
+	}
+}
+
+func TestEventSearchWhere(t *testing.T) {
+	f := EventSearch{}
+	where, args, err := f.where()
+	if err != nil {
+		t.Fatalf("where(): %v", err)
+	}
+	if where != "" || len(args) != 0 {
+		t.Errorf("empty filter should produce no WHERE clause, got %q %v", where, args)
+	}
+
+	f = EventSearch{Q: "trip", AccountID: 7}
+	where, args, err = f.where()
+	if err != nil {
+		t.Fatalf("where(): %v", err)
+	}
+	if where == "" || len(args) != 3 {
+		t.Errorf("Q+AccountID filter = %q %v, want a WHERE clause with 3 bound args", where, args)
+	}
+
+	f = EventSearch{Before: "not-a-time"}
+	if _, _, err := f.where(); err == nil {
+		t.Error("an invalid Before timestamp should be rejected")
+	}
+}
+
+func TestPersonSearchOrderBy(t *testing.T) {
+	if got := (&PersonSearch{Order: "title"}).orderBy(); got != " ORDER BY `last_name` ASC, `first_name` ASC" {
+		t.Errorf("PersonSearch order=title -> %q", got)
+	}
+	if got := (&PersonSearch{}).orderBy(); got != " ORDER BY `person_id` DESC" {
+		t.Errorf("PersonSearch default order -> %q", got)
+	}
+}
+
+func TestImageSearchWhereAndOrderBy(t *testing.T) {
+	f := ImageSearch{EventID: 3}
+	where, args, err := f.where()
+	if err != nil {
+		t.Fatalf("where(): %v", err)
+	}
+	if where != " WHERE `image_id` IN (SELECT `image_id` FROM `event_image_tagging` WHERE `event_id` = ?)" || len(args) != 1 {
+		t.Errorf("ImageSearch{EventID:3}.where() = %q %v", where, args)
+	}
+	if got := (&ImageSearch{Order: "oldest"}).orderBy(); got != " ORDER BY `image_id` ASC" {
+		t.Errorf("ImageSearch order=oldest -> %q", got)
+	}
+}
@@ -0,0 +1,127 @@
+// Package thumb generates and caches resized JPEG derivatives of the PNG
+// images stored by the main application.
+package thumb
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/sync/singleflight"
+)
+
+// Size describes one of the supported thumbnail dimensions.
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// Types maps a size name, as used in the URL, to its Size definition.
+var Types = map[string]Size{
+	"tile_100": {Name: "tile_100", Width: 100, Height: 100},
+	"fit_320":  {Name: "fit_320", Width: 320, Height: 320},
+	"fit_720":  {Name: "fit_720", Width: 720, Height: 720},
+	"fit_1280": {Name: "fit_1280", Width: 1280, Height: 1280},
+	"fit_2048": {Name: "fit_2048", Width: 2048, Height: 2048},
+}
+
+var group singleflight.Group
+
+const jpegQuality = 90
+
+// CachePath returns the on-disk location of the cached thumbnail for imageID/size.
+func CachePath(cacheDir, size string, imageID int) string {
+	return filepath.Join(cacheDir, size, fmt.Sprintf("%d.jpg", imageID))
+}
+
+// Generate resolves the cached thumbnail at size for imageID, rendering it
+// from srcPath on a cache miss. Concurrent calls for the same imageID/size
+// collapse onto a single render via singleflight.
+func Generate(srcPath, cacheDir, size string, imageID int) (string, error) {
+	sizeDef, ok := Types[size]
+	if !ok {
+		return "", fmt.Errorf("thumb: unknown size %q", size)
+	}
+
+	dstPath := CachePath(cacheDir, size, imageID)
+	if _, err := os.Stat(dstPath); err == nil {
+		return dstPath, nil
+	}
+
+	key := fmt.Sprintf("%s/%d", size, imageID)
+	_, err, _ := group.Do(key, func() (interface{}, error) {
+		return nil, render(srcPath, dstPath, sizeDef)
+	})
+	if err != nil {
+		return "", err
+	}
+	return dstPath, nil
+}
+
+// render decodes srcPath, resamples it to fit within sizeDef, and writes the result as a JPEG at dstPath.
+func render(srcPath, dstPath string, sizeDef Size) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	img, err := png.Decode(src)
+	if err != nil {
+		return err
+	}
+
+	dstRect := fitRect(img.Bounds(), sizeDef.Width, sizeDef.Height)
+	dstImg := image.NewRGBA(dstRect)
+	draw.CatmullRom.Scale(dstImg, dstRect, img, img.Bounds(), draw.Over, nil)
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
+		return err
+	}
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(dst, dstImg, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dstPath)
+}
+
+// fitRect returns the rectangle src scales into to fit maxWidth x maxHeight, preserving aspect ratio.
+func fitRect(src image.Rectangle, maxWidth, maxHeight int) image.Rectangle {
+	w, h := src.Dx(), src.Dy()
+	if w <= 0 || h <= 0 {
+		return image.Rect(0, 0, maxWidth, maxHeight)
+	}
+	scale := float64(maxWidth) / float64(w)
+	if alt := float64(maxHeight) / float64(h); alt < scale {
+		scale = alt
+	}
+	if scale > 1 {
+		scale = 1
+	}
+	return image.Rect(0, 0, int(float64(w)*scale), int(float64(h)*scale))
+}
+
+// ClearCache removes every cached derivative of imageID across all sizes.
+func ClearCache(cacheDir string, imageID int) error {
+	for size := range Types {
+		if err := os.Remove(CachePath(cacheDir, size, imageID)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package thumb
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFitRect(t *testing.T) {
+	cases := []struct {
+		w, h, maxW, maxH int
+		wantW, wantH     int
+	}{
+		{w: 2000, h: 1000, maxW: 1000, maxH: 1000, wantW: 1000, wantH: 500},
+		{w: 1000, h: 2000, maxW: 1000, maxH: 1000, wantW: 500, wantH: 1000},
+		{w: 100, h: 100, maxW: 1000, maxH: 1000, wantW: 100, wantH: 100},
+	}
+	for _, c := range cases {
+		got := fitRect(image.Rect(0, 0, c.w, c.h), c.maxW, c.maxH)
+		if got.Dx() != c.wantW || got.Dy() != c.wantH {
+			t.Errorf("fitRect(%dx%d, %d, %d) = %dx%d, want %dx%d",
+				c.w, c.h, c.maxW, c.maxH, got.Dx(), got.Dy(), c.wantW, c.wantH)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"poc_webapp/thumb"
+)
+
+const thumbsPath = "../public/thumbs"
+
+// thumbURLs builds the "thumbs" map embedded in ImageAndPath responses.
+func thumbURLs(imageID int) map[string]string {
+	urls := make(map[string]string, len(thumb.Types))
+	for size := range thumb.Types {
+		urls[size] = fmt.Sprintf("/api/images/%d/thumb/%s", imageID, size)
+	}
+	return urls
+}
+
+// GET /api/images/{image_id}/thumb/{size}
+// 画像のサムネイルを取得（初回はリサイズしてキャッシュ、以降はキャッシュを返す）
+func getImageThumb(c echo.Context) error {
+	imageIDParam := c.Param("image_id")
+	size := c.Param("size")
+
+	if _, ok := thumb.Types[size]; !ok {
+		return c.String(http.StatusNotFound, "not found: size")
+	}
+
+	imageID, err := strconv.Atoi(imageIDParam)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "bad image_id")
+	}
+
+	var image Image
+	err = db.Get(&image, "SELECT * FROM `images` WHERE `image_id` = ?", imageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.String(http.StatusNotFound, "not found: image")
+		}
+		c.Logger().Errorf("db error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	srcPath := fmt.Sprintf("%s/%d.png", imagesPath, imageID)
+	path, err := thumb.Generate(srcPath, thumbsPath, size, imageID)
+	if err != nil {
+		c.Logger().Errorf("thumb error: %v", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	etag := fmt.Sprintf(`"%d-%s"`, imageID, size)
+	c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	c.Response().Header().Set("ETag", etag)
+	if match := c.Request().Header.Get("If-None-Match"); match == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.File(path)
+}
+
+// ClearImageThumbCache removes every cached thumbnail derivative of imageID.
+func ClearImageThumbCache(imageID int) error {
+	return thumb.ClearCache(thumbsPath, imageID)
+}